@@ -0,0 +1,48 @@
+package posthog
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backo computes the delay to wait between retries of a failed operation,
+// growing exponentially between Min and Max. It is a small port of
+// https://github.com/segmentio/backo-go.
+type Backo struct {
+	Min    time.Duration
+	Max    time.Duration
+	Factor float64
+	Jitter float64
+}
+
+// DefaultBacko returns the retry policy used by a client when
+// Config.RetryAfter isn't set.
+func DefaultBacko() *Backo {
+	return &Backo{
+		Min:    100 * time.Millisecond,
+		Max:    10 * time.Second,
+		Factor: 2,
+	}
+}
+
+// Duration returns how long to wait before retrying the attempt-th time
+// (zero-indexed) an operation has failed.
+func (b *Backo) Duration(attempt int) time.Duration {
+	d := float64(b.Min) * math.Pow(b.Factor, float64(attempt))
+
+	if b.Jitter > 0 {
+		j := rand.Float64() * b.Jitter * d
+		if int64(j)%2 == 0 {
+			d -= j
+		} else {
+			d += j
+		}
+	}
+
+	if d > float64(b.Max) {
+		return b.Max
+	}
+
+	return time.Duration(d)
+}
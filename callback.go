@@ -0,0 +1,12 @@
+package posthog
+
+// Callback is the interface used by the client to notify the application
+// when messages sends to the backend API succeeded or failed.
+type Callback interface {
+	// Success is called when a message has been successfully delivered.
+	Success(APIMessage)
+
+	// Failure is called when a message has failed to be delivered, along
+	// with the error that caused the failure.
+	Failure(APIMessage, error)
+}
@@ -0,0 +1,451 @@
+package posthog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxSendAttempts bounds how many times a batch is attempted against a single
+// endpoint, using Config.RetryAfter between attempts, before it is
+// considered failed and handed to the dead letter path.
+const maxSendAttempts = 3
+
+// Client is the interface exposed to applications to capture events and
+// evaluate feature flags.
+type Client interface {
+	io.Closer
+
+	// Enqueue queues a message to be sent to the configured endpoint(s) on
+	// the next flush. Once the queue reaches Config.MaxQueueSize, what
+	// happens next is governed by Config.OverflowPolicy.
+	Enqueue(Message) error
+
+	// Stats returns a snapshot of the client's queue and delivery state,
+	// suitable for exporting to a metrics system.
+	Stats() Stats
+
+	// GetFeatureFlag returns the value of a feature flag for a distinct ID.
+	GetFeatureFlag(FeatureFlagPayload) (interface{}, error)
+
+	// IsFeatureEnabled reports whether a feature flag is enabled for a
+	// distinct ID.
+	IsFeatureEnabled(FeatureFlagPayload) (bool, error)
+}
+
+// client is the default implementation of Client.
+type client struct {
+	Config
+
+	msgs chan Message
+
+	// sem bounds how many requests may be in flight to endpoints at once,
+	// sized to maxConcurrentRequests. A nil sem (as in tests that build a
+	// client literal directly) means no limit is enforced.
+	sem chan struct{}
+
+	poller *featureFlagsPoller
+
+	statsMu sync.Mutex
+	stats   Stats
+
+	quit     chan struct{}
+	shutdown chan struct{}
+	once     sync.Once
+}
+
+// NewWithConfig creates a client that delivers messages tagged with apiKey
+// to the endpoint(s) described by config.
+func NewWithConfig(apiKey string, config Config) (Client, error) {
+	config = makeConfig(config)
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	for i := range config.Endpoints {
+		if len(config.Endpoints[i].APIKey) == 0 {
+			config.Endpoints[i].APIKey = apiKey
+		}
+	}
+
+	c := &client{
+		Config:   config,
+		msgs:     make(chan Message, config.MaxQueueSize),
+		sem:      make(chan struct{}, config.maxConcurrentRequests),
+		quit:     make(chan struct{}),
+		shutdown: make(chan struct{}),
+	}
+
+	c.poller = newFeatureFlagsPoller(c)
+
+	bootstrap, err := loadFlagsBootstrap(&c.Config)
+	if err != nil {
+		c.Logger.Errorf("posthog: failed to load feature flags bootstrap: %s", err)
+	} else {
+		c.poller.bootstrap(bootstrap)
+	}
+
+	go c.loop()
+	go c.poller.run()
+
+	return c, nil
+}
+
+func (c *client) Enqueue(msg Message) error {
+	if err := msg.Validate(); err != nil {
+		return err
+	}
+
+	select {
+	case c.msgs <- msg:
+		return nil
+	default:
+	}
+
+	switch c.OverflowPolicy {
+	case Block:
+		c.msgs <- msg
+		return nil
+
+	case ErrorPolicy:
+		c.recordDrop(ErrorPolicy)
+		return ErrQueueFull
+
+	case DropNewest:
+		c.recordDrop(DropNewest)
+		return nil
+
+	default: // DropOldest
+		select {
+		case <-c.msgs:
+			c.recordDrop(DropOldest)
+		default:
+		}
+
+		select {
+		case c.msgs <- msg:
+		default:
+			// Another producer refilled the queue between our drop and
+			// this send; treat the message as dropped rather than block.
+			c.recordDrop(DropOldest)
+		}
+
+		return nil
+	}
+}
+
+// Stats returns a point-in-time snapshot of the client's queue and delivery
+// state.
+func (c *client) Stats() Stats {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+
+	dropped := make(map[OverflowPolicy]uint64, len(c.stats.Dropped))
+	for policy, count := range c.stats.Dropped {
+		dropped[policy] = count
+	}
+
+	stats := c.stats
+	stats.Queued = len(c.msgs)
+	stats.Dropped = dropped
+	return stats
+}
+
+func (c *client) recordDrop(policy OverflowPolicy) {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+
+	if c.stats.Dropped == nil {
+		c.stats.Dropped = map[OverflowPolicy]uint64{}
+	}
+	c.stats.Dropped[policy]++
+}
+
+func (c *client) Close() error {
+	c.once.Do(func() {
+		close(c.quit)
+		<-c.shutdown
+		c.poller.stop()
+
+		if len(c.FlagsCachePath) != 0 {
+			if err := writeFlagsCache(c.FlagsCachePath, c.poller.snapshot()); err != nil {
+				c.Logger.Errorf("posthog: failed to persist flags cache: %s", err)
+			}
+		}
+	})
+
+	return nil
+}
+
+// loop batches enqueued messages and flushes them on Interval or once
+// BatchSize messages have accumulated, whichever comes first.
+func (c *client) loop() {
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	var batch []Message
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		c.flush(batch)
+		batch = nil
+	}
+
+	for {
+		select {
+		case msg := <-c.msgs:
+			batch = append(batch, msg)
+			if len(batch) >= c.BatchSize {
+				flush()
+			}
+
+		case <-ticker.C:
+			flush()
+
+		case <-c.quit:
+			flush()
+			close(c.shutdown)
+			return
+		}
+	}
+}
+
+// flush marshals msgs into a single batch and delivers it to Config.Sink if
+// one is set, otherwise to every configured endpoint concurrently.
+func (c *client) flush(msgs []Message) {
+	apiMsgs := make([]APIMessage, len(msgs))
+	for i, msg := range msgs {
+		apiMsgs[i] = msg.APIfy()
+	}
+
+	body, err := json.Marshal(apiMsgs)
+	if err != nil {
+		c.Logger.Errorf("posthog: marshaling batch of %d messages: %s", len(msgs), err)
+		return
+	}
+
+	c.statsMu.Lock()
+	c.stats.InFlight++
+	c.statsMu.Unlock()
+
+	start := c.now()
+
+	if c.Sink != nil {
+		c.deliverToSink(apiMsgs, body)
+	} else {
+		c.deliver(apiMsgs, body)
+	}
+
+	c.statsMu.Lock()
+	c.stats.InFlight--
+	c.stats.LastFlushLatency = c.now().Sub(start)
+	c.statsMu.Unlock()
+}
+
+// acquireSendSlot blocks until fewer than Config.MaxConcurrentRequests
+// requests are in flight. It's a no-op when sem is nil.
+func (c *client) acquireSendSlot() {
+	if c.sem != nil {
+		c.sem <- struct{}{}
+	}
+}
+
+func (c *client) releaseSendSlot() {
+	if c.sem != nil {
+		<-c.sem
+	}
+}
+
+func (c *client) recordBytesSent(n int) {
+	c.statsMu.Lock()
+	c.stats.BytesSent += uint64(n)
+	c.statsMu.Unlock()
+}
+
+// deliverToSink hands body to the configured Sink instead of posting it to
+// an endpoint, dead-lettering it on failure.
+func (c *client) deliverToSink(apiMsgs []APIMessage, body []byte) {
+	err := c.Sink.Send(body)
+	if err != nil {
+		c.Logger.Errorf("posthog: sink failed to deliver batch of %d messages: %s", len(apiMsgs), err)
+		c.deadLetterSink(body, err)
+		c.notify(apiMsgs, err)
+		return
+	}
+
+	c.recordBytesSent(len(body))
+	c.notify(apiMsgs, nil)
+}
+
+// notify calls Callback.Success or Callback.Failure once for every message
+// in the batch, so that a batch of N messages produces N callbacks rather
+// than a single callback representing the whole batch.
+func (c *client) notify(apiMsgs []APIMessage, err error) {
+	if c.Callback == nil {
+		return
+	}
+
+	for _, msg := range apiMsgs {
+		if err != nil {
+			c.Callback.Failure(msg, err)
+		} else {
+			c.Callback.Success(msg)
+		}
+	}
+}
+
+// deliver sends body to every configured endpoint concurrently, reporting
+// each endpoint's outcome through Callback independently so that one
+// failing destination never masks or blocks the others.
+func (c *client) deliver(apiMsgs []APIMessage, body []byte) {
+	results := make([]EndpointResult, len(c.Endpoints))
+
+	var wg sync.WaitGroup
+	for i, endpoint := range c.Endpoints {
+		wg.Add(1)
+		go func(i int, endpoint EndpointConfig) {
+			defer wg.Done()
+			attempts, responseBody, err := c.sendToEndpoint(endpoint, body)
+			results[i] = EndpointResult{
+				Endpoint:     endpoint,
+				Attempts:     attempts,
+				ResponseBody: responseBody,
+				Err:          err,
+			}
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		if result.Err != nil {
+			err := fmt.Errorf("endpoint %s: %w", result.Endpoint.URL, result.Err)
+			c.Logger.Errorf("posthog: failed to deliver batch of %d messages: %s", len(apiMsgs), err)
+			c.deadLetterEndpoint(result.Endpoint, body, result.Attempts, result.ResponseBody)
+			c.notify(apiMsgs, err)
+			continue
+		}
+
+		c.recordBytesSent(len(body))
+		c.notify(apiMsgs, nil)
+	}
+}
+
+// deadLetterEndpoint persists body to Config.DeadLetter, if configured,
+// recording which endpoint it failed to reach so ReplayDeadLetter can
+// resend it to that endpoint alone instead of fanning it out to every
+// endpoint again.
+func (c *client) deadLetterEndpoint(endpoint EndpointConfig, body []byte, attempts int, responseBody []byte) {
+	c.writeDeadLetterEntry(DeadLetterEntry{
+		Endpoint:     endpoint,
+		Attempts:     attempts,
+		ResponseBody: string(responseBody),
+		Body:         json.RawMessage(body),
+	})
+}
+
+// deadLetterSink persists body to Config.DeadLetter, if configured, for a
+// batch that failed to reach the configured Sink. Sinks have no notion of a
+// partial, multi-destination fan-out, so the whole batch is recorded
+// against a single attempt.
+func (c *client) deadLetterSink(body []byte, sendErr error) {
+	c.writeDeadLetterEntry(DeadLetterEntry{
+		Attempts:     1,
+		ResponseBody: sendErr.Error(),
+		Body:         json.RawMessage(body),
+	})
+}
+
+func (c *client) writeDeadLetterEntry(entry DeadLetterEntry) {
+	if len(c.DeadLetter) == 0 {
+		return
+	}
+
+	entry.FirstSeen = c.now()
+
+	if err := writeDeadLetter(c.DeadLetter, entry); err != nil {
+		c.Logger.Errorf("posthog: failed to persist dead letter entry: %s", err)
+	}
+}
+
+// sendToEndpoint posts body to endpoint, retrying with the endpoint's (or
+// the client's) RetryAfter policy until it succeeds or maxSendAttempts is
+// reached. It returns how many attempts were actually made and the body of
+// the last HTTP response received, if any, so callers can record accurate
+// dead letter metadata.
+func (c *client) sendToEndpoint(endpoint EndpointConfig, body []byte) (attempts int, responseBody []byte, err error) {
+	retryAfter := endpoint.RetryAfter
+	if retryAfter == nil {
+		retryAfter = c.RetryAfter
+	}
+
+	transport := endpoint.Transport
+	if transport == nil {
+		transport = c.Transport
+	}
+
+	for attempts = 1; attempts <= maxSendAttempts; attempts++ {
+		if attempts > 1 {
+			time.Sleep(retryAfter(attempts - 2))
+		}
+
+		c.acquireSendSlot()
+		responseBody, err = postBatchToEndpoint(endpoint, transport, body)
+		c.releaseSendSlot()
+
+		if err == nil {
+			return attempts, responseBody, nil
+		}
+	}
+
+	return attempts - 1, responseBody, err
+}
+
+// postBatchToEndpoint posts body to endpoint once, over transport (or
+// http.DefaultTransport if nil), and returns the response body it
+// received, whether or not the request ultimately succeeded. It does not
+// retry and does not use a client's send-slot semaphore, so it can also be
+// used by ReplayDeadLetter, which replays a single entry outside of any
+// client's lifecycle.
+func postBatchToEndpoint(endpoint EndpointConfig, transport http.RoundTripper, body []byte) ([]byte, error) {
+	req, err := http.NewRequest("POST", endpoint.URL+"/batch/", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for name, values := range endpoint.Headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	if len(endpoint.APIKey) != 0 {
+		req.Header.Set("Authorization", "Bearer "+endpoint.APIKey)
+	}
+
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return responseBody, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return responseBody, nil
+}
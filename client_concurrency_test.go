@@ -0,0 +1,63 @@
+package posthog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxConcurrentRequestsBoundsInFlightSends(t *testing.T) {
+	var inFlight, maxObserved int32
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		for {
+			observed := atomic.LoadInt32(&maxObserved)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxObserved, observed, current) {
+				break
+			}
+		}
+		<-release
+		atomic.AddInt32(&inFlight, -1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	const concurrencyLimit = 2
+	const endpointCount = 5
+
+	endpoints := make([]EndpointConfig, endpointCount)
+	for i := range endpoints {
+		endpoints[i] = EndpointConfig{URL: server.URL}
+	}
+
+	c := &client{
+		Config: Config{
+			Endpoints:             endpoints,
+			Transport:             http.DefaultTransport,
+			Logger:                newDefaultLogger(),
+			now:                   time.Now,
+			maxConcurrentRequests: concurrencyLimit,
+		},
+		sem: make(chan struct{}, concurrencyLimit),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.deliver([]APIMessage{{Type: "capture"}}, []byte(`[{"type":"capture"}]`))
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&maxObserved); got > concurrencyLimit {
+		t.Errorf("expected at most %d concurrent requests, observed %d", concurrencyLimit, got)
+	}
+}
@@ -0,0 +1,135 @@
+package posthog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDeliverDeadLettersRecordActualAttemptsAndResponseBody(t *testing.T) {
+	dir := t.TempDir()
+
+	var hits int32
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("server is on fire"))
+	}))
+	defer failing.Close()
+
+	c := &client{
+		Config: Config{
+			Endpoints:  []EndpointConfig{{URL: failing.URL, RetryAfter: func(int) time.Duration { return 0 }}},
+			DeadLetter: dir,
+			Transport:  http.DefaultTransport,
+			Logger:     newDefaultLogger(),
+			now:        time.Now,
+		},
+	}
+
+	c.deliver([]APIMessage{{Type: "capture"}}, []byte(`[{"type":"capture"}]`))
+
+	if got := atomic.LoadInt32(&hits); got != maxSendAttempts {
+		t.Fatalf("expected %d attempts against the failing endpoint, observed %d", maxSendAttempts, got)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("reading dead letter dir: %s", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected exactly one dead-lettered entry, got %d", len(entries))
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	if err != nil {
+		t.Fatalf("reading dead letter entry: %s", err)
+	}
+
+	var entry DeadLetterEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("decoding dead letter entry: %s", err)
+	}
+
+	if entry.Attempts != maxSendAttempts {
+		t.Errorf("expected Attempts to record the actual number of tries (%d), got %d", maxSendAttempts, entry.Attempts)
+	}
+
+	if entry.ResponseBody != "server is on fire" {
+		t.Errorf("expected ResponseBody to hold the real HTTP response body, got %q", entry.ResponseBody)
+	}
+
+	if entry.Endpoint.URL != failing.URL {
+		t.Errorf("expected the dead letter entry to record the failing endpoint, got %q", entry.Endpoint.URL)
+	}
+}
+
+func TestDeliverDeadLettersOnlyTheFailingEndpointOnPartialFanOutFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	var okHits int32
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&okHits, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ok.Close()
+
+	var failingHits int32
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail for the original delivery's retries, then succeed once
+		// replayed, so the test can tell a genuine replay from a no-op.
+		if atomic.AddInt32(&failingHits, 1) <= maxSendAttempts {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer failing.Close()
+
+	c := &client{
+		Config: Config{
+			Endpoints: []EndpointConfig{
+				{URL: ok.URL, RetryAfter: func(int) time.Duration { return 0 }},
+				{URL: failing.URL, RetryAfter: func(int) time.Duration { return 0 }},
+			},
+			DeadLetter: dir,
+			Transport:  http.DefaultTransport,
+			Logger:     newDefaultLogger(),
+			now:        time.Now,
+		},
+	}
+
+	c.deliver([]APIMessage{{Type: "capture"}}, []byte(`[{"type":"capture"}]`))
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("reading dead letter dir: %s", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected exactly one dead-lettered entry for the one failing endpoint, got %d", len(entries))
+	}
+
+	if got := atomic.LoadInt32(&okHits); got != 1 {
+		t.Fatalf("expected the healthy endpoint to be hit exactly once, got %d", got)
+	}
+
+	// Replaying the dead letter should resend only to the endpoint it
+	// actually failed against, not to every configured endpoint, so the
+	// already-succeeded endpoint doesn't receive the batch a second time.
+	replayed, err := ReplayDeadLetter(dir, nil)
+	if err != nil {
+		t.Fatalf("ReplayDeadLetter: %s", err)
+	}
+	if replayed != 1 {
+		t.Fatalf("expected 1 replayed entry, got %d", replayed)
+	}
+
+	if got := atomic.LoadInt32(&okHits); got != 1 {
+		t.Errorf("expected replay not to re-deliver to the already-succeeded endpoint, hits now %d", got)
+	}
+}
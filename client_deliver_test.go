@@ -0,0 +1,109 @@
+package posthog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type recordingCallback struct {
+	mu        sync.Mutex
+	successes int
+	failures  []error
+}
+
+func (r *recordingCallback) Success(APIMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.successes++
+}
+
+func (r *recordingCallback) Failure(_ APIMessage, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures = append(r.failures, err)
+}
+
+func TestDeliverFansOutToEveryEndpointAndReportsIndependently(t *testing.T) {
+	var okHits int32
+
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&okHits, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ok.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	callback := &recordingCallback{}
+
+	c := &client{
+		Config: Config{
+			Endpoints: []EndpointConfig{
+				{URL: ok.URL, RetryAfter: func(int) time.Duration { return 0 }},
+				{URL: failing.URL, RetryAfter: func(int) time.Duration { return 0 }},
+			},
+			Callback:  callback,
+			Transport: http.DefaultTransport,
+			Logger:    newDefaultLogger(),
+			now:       time.Now,
+		},
+	}
+
+	c.deliver([]APIMessage{{Type: "capture", Event: "test"}}, []byte(`[{"type":"capture"}]`))
+
+	if atomic.LoadInt32(&okHits) != 1 {
+		t.Errorf("expected the healthy endpoint to receive the batch, got %d hits", okHits)
+	}
+
+	callback.mu.Lock()
+	defer callback.mu.Unlock()
+
+	if callback.successes != 1 {
+		t.Errorf("expected exactly one success callback, got %d", callback.successes)
+	}
+
+	if len(callback.failures) != 1 {
+		t.Errorf("expected exactly one failure callback, got %d", len(callback.failures))
+	}
+}
+
+func TestDeliverNotifiesCallbackForEveryMessageInBatch(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ok.Close()
+
+	callback := &recordingCallback{}
+
+	c := &client{
+		Config: Config{
+			Endpoints: []EndpointConfig{{URL: ok.URL}},
+			Callback:  callback,
+			Transport: http.DefaultTransport,
+			Logger:    newDefaultLogger(),
+			now:       time.Now,
+		},
+	}
+
+	apiMsgs := []APIMessage{
+		{Type: "capture", Event: "one"},
+		{Type: "capture", Event: "two"},
+		{Type: "capture", Event: "three"},
+	}
+
+	c.deliver(apiMsgs, []byte(`[{"type":"capture"}]`))
+
+	callback.mu.Lock()
+	defer callback.mu.Unlock()
+
+	if callback.successes != len(apiMsgs) {
+		t.Errorf("expected a success callback per message (%d), got %d", len(apiMsgs), callback.successes)
+	}
+}
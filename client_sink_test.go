@@ -0,0 +1,96 @@
+package posthog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFlushPrefersSinkOverEndpoints(t *testing.T) {
+	var received []byte
+
+	c := &client{
+		Config: Config{
+			Logger: newDefaultLogger(),
+			Sink: SinkFunc(func(body []byte) error {
+				received = body
+				return nil
+			}),
+			now: time.Now,
+		},
+	}
+
+	c.flush([]Message{Capture{DistinctId: "user", Event: "test"}})
+
+	if received == nil {
+		t.Fatal("expected the sink to receive the flushed batch")
+	}
+}
+
+func TestFlushDeadLettersOnSinkFailure(t *testing.T) {
+	dir := t.TempDir()
+	dlPath := filepath.Join(dir, "dead-letters")
+
+	c := &client{
+		Config: Config{
+			Logger:     newDefaultLogger(),
+			DeadLetter: dlPath,
+			Sink: SinkFunc(func(body []byte) error {
+				return errors.New("boom")
+			}),
+			now: time.Now,
+		},
+	}
+
+	c.flush([]Message{Capture{DistinctId: "user", Event: "test"}})
+
+	entries, err := os.ReadDir(dlPath)
+	if err != nil {
+		t.Fatalf("reading dead letter dir: %s", err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("expected exactly one dead-lettered entry, got %d", len(entries))
+	}
+}
+
+func TestReplayDeadLetterRedeliversAndRemovesEntries(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := writeDeadLetter(dir, DeadLetterEntry{
+		FirstSeen: time.Now(),
+		Body:      []byte(`[{"type":"capture"}]`),
+	}); err != nil {
+		t.Fatalf("writeDeadLetter: %s", err)
+	}
+
+	var replayedBody []byte
+	sink := SinkFunc(func(body []byte) error {
+		replayedBody = body
+		return nil
+	})
+
+	replayed, err := ReplayDeadLetter(dir, sink)
+	if err != nil {
+		t.Fatalf("ReplayDeadLetter: %s", err)
+	}
+
+	if replayed != 1 {
+		t.Fatalf("expected 1 replayed entry, got %d", replayed)
+	}
+
+	if string(replayedBody) != `[{"type":"capture"}]` {
+		t.Errorf("unexpected replayed body: %s", replayedBody)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("reading dead letter dir: %s", err)
+	}
+
+	if len(entries) != 0 {
+		t.Errorf("expected the replayed entry to be removed, got %d remaining", len(entries))
+	}
+}
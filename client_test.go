@@ -0,0 +1,118 @@
+package posthog
+
+import (
+	"testing"
+	"time"
+)
+
+func fillQueue(t *testing.T, c *client, n int) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		if err := c.Enqueue(Capture{DistinctId: "user", Event: "filler"}); err != nil {
+			t.Fatalf("unexpected error filling queue: %s", err)
+		}
+	}
+}
+
+func TestEnqueueDropNewestWhenQueueFull(t *testing.T) {
+	c := &client{
+		Config: Config{OverflowPolicy: DropNewest, MaxQueueSize: 1},
+		msgs:   make(chan Message, 1),
+	}
+
+	fillQueue(t, c, 1)
+
+	if err := c.Enqueue(Capture{DistinctId: "user", Event: "overflow"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(c.msgs) != 1 {
+		t.Fatalf("expected queue length 1, got %d", len(c.msgs))
+	}
+
+	if got := c.Stats().Dropped[DropNewest]; got != 1 {
+		t.Errorf("expected 1 dropped message under DropNewest, got %d", got)
+	}
+}
+
+func TestEnqueueDropOldestWhenQueueFull(t *testing.T) {
+	c := &client{
+		Config: Config{OverflowPolicy: DropOldest, MaxQueueSize: 1},
+		msgs:   make(chan Message, 1),
+	}
+
+	fillQueue(t, c, 1)
+
+	if err := c.Enqueue(Capture{DistinctId: "user", Event: "overflow"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(c.msgs) != 1 {
+		t.Fatalf("expected queue length 1, got %d", len(c.msgs))
+	}
+
+	queued := (<-c.msgs).APIfy()
+	if queued.Event != "overflow" {
+		t.Errorf("expected the newest message to replace the oldest, got %q", queued.Event)
+	}
+
+	if got := c.Stats().Dropped[DropOldest]; got != 1 {
+		t.Errorf("expected 1 dropped message under DropOldest, got %d", got)
+	}
+}
+
+func TestEnqueueErrorPolicyWhenQueueFull(t *testing.T) {
+	c := &client{
+		Config: Config{OverflowPolicy: ErrorPolicy, MaxQueueSize: 1},
+		msgs:   make(chan Message, 1),
+	}
+
+	fillQueue(t, c, 1)
+
+	if err := c.Enqueue(Capture{DistinctId: "user", Event: "overflow"}); err != ErrQueueFull {
+		t.Errorf("expected ErrQueueFull, got %v", err)
+	}
+}
+
+func TestEnqueueBlockWaitsForRoom(t *testing.T) {
+	c := &client{
+		Config: Config{OverflowPolicy: Block, MaxQueueSize: 1},
+		msgs:   make(chan Message, 1),
+	}
+
+	fillQueue(t, c, 1)
+
+	done := make(chan struct{})
+	go func() {
+		c.Enqueue(Capture{DistinctId: "user", Event: "overflow"})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("expected Enqueue to block while the queue is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	<-c.msgs // make room
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Enqueue to unblock once the queue had room")
+	}
+}
+
+func TestStatsReportsQueuedLength(t *testing.T) {
+	c := &client{
+		Config: Config{OverflowPolicy: DropOldest, MaxQueueSize: 2},
+		msgs:   make(chan Message, 2),
+	}
+
+	fillQueue(t, c, 2)
+
+	if got := c.Stats().Queued; got != 2 {
+		t.Errorf("expected 2 queued messages, got %d", got)
+	}
+}
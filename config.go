@@ -2,6 +2,7 @@ package posthog
 
 import (
 	"net/http"
+	"os"
 	"time"
 )
 
@@ -14,8 +15,20 @@ type Config struct {
 
 	// The endpoint to which the client connect and send their messages, set to
 	// `DefaultEndpoint` by default.
+	//
+	// If `Endpoints` is also set, this field is ignored in favor of it.
 	Endpoint string
 
+	// A list of endpoints to fan messages out to. When set, every batch is
+	// sent to each endpoint concurrently, each with its own URL, API key,
+	// headers and transport/retry overrides. This is useful for mirroring
+	// events to a self-hosted instance alongside PostHog Cloud, or to
+	// staging and production projects at once.
+	//
+	// If left empty, the client falls back to a single endpoint built from
+	// `Endpoint` and `config`'s other top-level options.
+	Endpoints []EndpointConfig
+
 	// Specifying a Personal API key will make feature flag evaluation more performant,
 	// but it's not required for feature flags.  If you don't have a personal API key,
 	// you can leave this field empty, and all of the relevant feature flag evaluation
@@ -38,6 +51,53 @@ type Config struct {
 	// will override DefaultFeatureFlagsPollingInterval.
 	NextFeatureFlagsPollingTick func() time.Duration
 
+	// When set to true, each feature flag polling tick is perturbed by a
+	// uniformly random offset in [-PollingJitter, +PollingJitter], which
+	// prevents many instances of a service that boot at the same time from
+	// polling in lockstep. If PollingJitter is left at zero while this is
+	// enabled, it defaults to 10% of DefaultFeatureFlagsPollingInterval.
+	EnablePollingJitter bool
+
+	// The maximum random offset applied to each polling tick when
+	// EnablePollingJitter is set. See EnablePollingJitter for details.
+	PollingJitter time.Duration
+
+	// When set to true, GetFeatureFlag and IsFeatureEnabled will not
+	// automatically capture a `$feature_flag_called` event on every
+	// evaluation. Useful for high-throughput services that evaluate flags
+	// on every request and don't need per-evaluation analytics.
+	//
+	// Plain on/off switch: every bool value is valid, and "off" (false) is
+	// the correct zero value, so there's nothing for validate() to reject
+	// or for makeConfig() to default.
+	DisableFeatureFlagCalledEvents bool
+
+	// When set to true, Callback.Success is not invoked for the first
+	// feature flag definitions load that completes after the client
+	// starts, only for reloads that happen afterwards. This avoids a noisy
+	// notification on every deploy for applications that treat the
+	// callback as a "flags changed" signal.
+	//
+	// Plain on/off switch: every bool value is valid, and "off" (false) is
+	// the correct zero value, so there's nothing for validate() to reject
+	// or for makeConfig() to default.
+	DisableNotifierOnInit bool
+
+	// When set, the client writes the feature flag definitions and cohort
+	// payload it has loaded to this path on a graceful Close(), and reads
+	// them back from the same path on NewWithConfig if FlagsBootstrap is
+	// not set. This lets local flag evaluation work immediately on process
+	// start instead of returning defaults until the first poll completes,
+	// which matters for short-lived jobs and cold-start serverless
+	// functions.
+	FlagsCachePath string
+
+	// The feature flag definitions and cohort payload to load synchronously
+	// before the first poll, in the same JSON format written to
+	// FlagsCachePath. Takes precedence over FlagsCachePath when both are
+	// set.
+	FlagsBootstrap []byte
+
 	// The HTTP transport used by the client, this allows an application to
 	// redefine how requests are being sent at the HTTP level (for example,
 	// to change the connection pooling policy).
@@ -59,6 +119,20 @@ type Config struct {
 	// application when messages sends to the backend API succeeded or failed.
 	Callback Callback
 
+	// When set, batches bypass `Endpoint`/`Endpoints` entirely and are
+	// handed to this Sink instead, which is useful to divert or mirror
+	// captured events to arbitrary destinations, e.g. a webhook, an NDJSON
+	// file, or a user-supplied function. Left unset, the client posts
+	// batches over HTTP to `Endpoint`/`Endpoints`, which is the behavior of
+	// every prior version of this library.
+	Sink Sink
+
+	// When set, batches that exhaust RetryAfter are persisted to this path
+	// instead of being dropped, so that they can be inspected or replayed
+	// later with ReplayDeadLetter. Left empty, exhausted batches are
+	// dropped as before.
+	DeadLetter string
+
 	// The maximum number of messages that will be sent in one API call.
 	// Messages will be sent when they've been queued up to the maximum batch
 	// size or when the flushing interval timer triggers.
@@ -66,6 +140,19 @@ type Config struct {
 	// which is independent from the number of embedded messages.
 	BatchSize int
 
+	// The maximum number of messages the client will hold in its internal
+	// queue while waiting to flush them. Defaults to `DefaultMaxQueueSize`.
+	// Once reached, OverflowPolicy decides what Enqueue does next.
+	MaxQueueSize int
+
+	// What the client does when Enqueue is called while the queue is at
+	// MaxQueueSize. Defaults to `DropOldest`.
+	OverflowPolicy OverflowPolicy
+
+	// The maximum number of requests the client will have in flight to the
+	// backend API at once. Defaults to 1000.
+	MaxConcurrentRequests int
+
 	// When set to true the client will send more frequent and detailed messages
 	// to its logger.
 	Verbose bool
@@ -108,6 +195,14 @@ const DefaultFeatureFlagRequestTimeout = 3 * time.Second
 // was explicitly set.
 const DefaultBatchSize = 250
 
+// This constant sets the default maximum number of queued messages a client
+// holds before OverflowPolicy kicks in, if none was explicitly set.
+const DefaultMaxQueueSize = 10000
+
+// This constant sets the default maximum number of requests a client has in
+// flight at once, if none was explicitly set.
+const DefaultMaxConcurrentRequests = 1000
+
 // Verifies that fields that don't have zero-values are set to valid values,
 // returns an error describing the problem if a field was invalid.
 func (c *Config) validate() error {
@@ -127,6 +222,68 @@ func (c *Config) validate() error {
 		}
 	}
 
+	if c.MaxQueueSize < 0 {
+		return ConfigError{
+			Reason: "negative queue sizes are not supported",
+			Field:  "MaxQueueSize",
+			Value:  c.MaxQueueSize,
+		}
+	}
+
+	if c.MaxConcurrentRequests < 0 {
+		return ConfigError{
+			Reason: "negative concurrent request limits are not supported",
+			Field:  "MaxConcurrentRequests",
+			Value:  c.MaxConcurrentRequests,
+		}
+	}
+
+	if !c.OverflowPolicy.valid() {
+		return ConfigError{
+			Reason: "unknown overflow policy",
+			Field:  "OverflowPolicy",
+			Value:  c.OverflowPolicy,
+		}
+	}
+
+	if c.PollingJitter < 0 {
+		return ConfigError{
+			Reason: "negative polling jitter is not supported",
+			Field:  "PollingJitter",
+			Value:  c.PollingJitter,
+		}
+	}
+
+	if len(c.DeadLetter) != 0 {
+		if info, err := os.Stat(c.DeadLetter); err == nil && !info.IsDir() {
+			return ConfigError{
+				Reason: "dead letter path must be a directory",
+				Field:  "DeadLetter",
+				Value:  c.DeadLetter,
+			}
+		}
+	}
+
+	if len(c.FlagsCachePath) != 0 {
+		if info, err := os.Stat(c.FlagsCachePath); err == nil && info.IsDir() {
+			return ConfigError{
+				Reason: "flags cache path must be a file, not a directory",
+				Field:  "FlagsCachePath",
+				Value:  c.FlagsCachePath,
+			}
+		}
+	}
+
+	for _, endpoint := range c.Endpoints {
+		if len(endpoint.URL) == 0 {
+			return ConfigError{
+				Reason: "endpoints must have a non-empty URL",
+				Field:  "Endpoints",
+				Value:  endpoint,
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -149,6 +306,10 @@ func makeConfig(c Config) Config {
 		c.FeatureFlagRequestTimeout = DefaultFeatureFlagRequestTimeout
 	}
 
+	if c.EnablePollingJitter && c.PollingJitter == 0 {
+		c.PollingJitter = time.Duration(float64(c.DefaultFeatureFlagsPollingInterval) * 0.1)
+	}
+
 	if c.Transport == nil {
 		c.Transport = http.DefaultTransport
 	}
@@ -161,16 +322,38 @@ func makeConfig(c Config) Config {
 		c.BatchSize = DefaultBatchSize
 	}
 
+	if c.MaxQueueSize == 0 {
+		c.MaxQueueSize = DefaultMaxQueueSize
+	}
+
+	if c.MaxConcurrentRequests == 0 {
+		c.MaxConcurrentRequests = DefaultMaxConcurrentRequests
+	}
+
 	if c.RetryAfter == nil {
 		c.RetryAfter = DefaultBacko().Duration
 	}
 
+	if len(c.Endpoints) == 0 {
+		// The project API key used to authenticate batch sends is not known
+		// here: it's the key passed to NewWithConfig, not PersonalApiKey
+		// (which is only used for local feature flag evaluation). It's
+		// backfilled onto this endpoint by NewWithConfig.
+		c.Endpoints = []EndpointConfig{
+			{
+				URL:        c.Endpoint,
+				Transport:  c.Transport,
+				RetryAfter: c.RetryAfter,
+			},
+		}
+	}
+
 	if c.now == nil {
 		c.now = time.Now
 	}
 
 	if c.maxConcurrentRequests == 0 {
-		c.maxConcurrentRequests = 1000
+		c.maxConcurrentRequests = c.MaxConcurrentRequests
 	}
 
 	return c
@@ -0,0 +1,111 @@
+package posthog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DeadLetterEntry is the on-disk representation of a batch that could not be
+// delivered after exhausting Config.RetryAfter.
+type DeadLetterEntry struct {
+	// Endpoint identifies the destination the batch failed to reach, so
+	// that ReplayDeadLetter can resend it to that endpoint alone rather
+	// than fanning it out to every configured endpoint again, which would
+	// re-deliver to destinations that already succeeded. Left zero-valued
+	// for batches that failed to reach a Sink instead of an endpoint.
+	Endpoint EndpointConfig `json:"endpoint,omitempty"`
+
+	Attempts     int             `json:"attempts"`
+	FirstSeen    time.Time       `json:"first_seen"`
+	ResponseBody string          `json:"response_body"`
+	Body         json.RawMessage `json:"body"`
+}
+
+// writeDeadLetter persists entry under dir, naming the file after its
+// first-seen timestamp so ReplayDeadLetter processes entries in the order
+// they originally failed. The write is atomic: the entry is written to a
+// temporary file first and then renamed into place.
+func writeDeadLetter(dir string, entry DeadLetterEntry) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("posthog: creating dead letter dir %q: %w", dir, err)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("posthog: marshaling dead letter entry: %w", err)
+	}
+
+	name := fmt.Sprintf("%d.json", entry.FirstSeen.UnixNano())
+	dst := filepath.Join(dir, name)
+	tmp := dst + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("posthog: writing dead letter entry: %w", err)
+	}
+
+	return os.Rename(tmp, dst)
+}
+
+// ReplayDeadLetter reads every dead-lettered batch under path, in the order
+// they were first seen, and resends each to the destination it originally
+// failed to reach: an entry recorded against a specific Endpoint is posted
+// directly back to that endpoint, while an entry recorded against a Sink
+// (Endpoint left unset) is sent through sink. This keeps replay from
+// double-delivering a batch to endpoints that already succeeded during the
+// original, partially-failed fan-out. Entries that are sent successfully
+// are removed from disk; entries are left in place on failure so a later
+// call can retry them. It returns the number of batches successfully
+// replayed.
+func ReplayDeadLetter(path string, sink Sink) (int, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("posthog: reading dead letter dir %q: %w", path, err)
+	}
+
+	replayed := 0
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		full := filepath.Join(path, entry.Name())
+
+		data, err := os.ReadFile(full)
+		if err != nil {
+			return replayed, fmt.Errorf("posthog: reading dead letter entry %q: %w", entry.Name(), err)
+		}
+
+		var dl DeadLetterEntry
+		if err := json.Unmarshal(data, &dl); err != nil {
+			return replayed, fmt.Errorf("posthog: decoding dead letter entry %q: %w", entry.Name(), err)
+		}
+
+		var sendErr error
+		if len(dl.Endpoint.URL) != 0 {
+			_, sendErr = postBatchToEndpoint(dl.Endpoint, dl.Endpoint.Transport, dl.Body)
+		} else if sink != nil {
+			sendErr = sink.Send(dl.Body)
+		} else {
+			sendErr = fmt.Errorf("posthog: no sink configured to replay entry %q to", entry.Name())
+		}
+
+		if sendErr != nil {
+			continue
+		}
+
+		if err := os.Remove(full); err != nil {
+			return replayed, fmt.Errorf("posthog: removing replayed dead letter entry %q: %w", entry.Name(), err)
+		}
+
+		replayed++
+	}
+
+	return replayed, nil
+}
@@ -0,0 +1,51 @@
+package posthog
+
+import (
+	"net/http"
+	"time"
+)
+
+// EndpointConfig describes a single destination that captured messages are
+// delivered to. A Config may list several of these in `Endpoints` to fan
+// batches out to multiple PostHog projects or self-hosted instances at once.
+type EndpointConfig struct {
+
+	// The URL that batches are posted to, e.g. `DefaultEndpoint`.
+	URL string `json:"url"`
+
+	// The API key used to authenticate with this endpoint.
+	APIKey string `json:"api_key,omitempty"`
+
+	// Extra HTTP headers sent with every request to this endpoint, useful
+	// for multi-tenant setups that route on a header rather than the API
+	// key alone.
+	Headers http.Header `json:"headers,omitempty"`
+
+	// Overrides the client-wide Transport for requests sent to this
+	// endpoint. If nil, the client's Transport is used. Not persisted to
+	// dead letter entries; a replay uses http.DefaultTransport.
+	Transport http.RoundTripper `json:"-"`
+
+	// Overrides the client-wide RetryAfter policy for requests sent to this
+	// endpoint. If nil, the client's RetryAfter is used. Not persisted to
+	// dead letter entries.
+	RetryAfter func(int) time.Duration `json:"-"`
+}
+
+// EndpointResult carries the outcome of delivering a single batch to a
+// single endpoint, so that a fan-out send can report per-endpoint
+// success/failure through Callback without one failing destination masking
+// the others.
+type EndpointResult struct {
+	Endpoint EndpointConfig
+
+	// Attempts is how many times delivery to Endpoint was actually tried
+	// before Err was returned (or before it succeeded, if Err is nil).
+	Attempts int
+
+	// ResponseBody is the body of the last HTTP response received from
+	// Endpoint, if any request reached it.
+	ResponseBody []byte
+
+	Err error
+}
@@ -0,0 +1,68 @@
+package posthog
+
+import "testing"
+
+func TestConfigValidateRejectsEndpointWithoutURL(t *testing.T) {
+	config := makeConfig(Config{Endpoints: []EndpointConfig{{}}})
+
+	if err := config.validate(); err == nil {
+		t.Error("expected an error for an endpoint without a URL")
+	}
+}
+
+func TestMakeConfigDefaultsEndpointsFromEndpoint(t *testing.T) {
+	config := makeConfig(Config{Endpoint: "https://example.com"})
+
+	if len(config.Endpoints) != 1 {
+		t.Fatalf("expected exactly one default endpoint, got %d", len(config.Endpoints))
+	}
+
+	if config.Endpoints[0].URL != "https://example.com" {
+		t.Errorf("expected default endpoint URL %q, got %q", "https://example.com", config.Endpoints[0].URL)
+	}
+
+	if len(config.Endpoints[0].APIKey) != 0 {
+		t.Errorf("expected makeConfig to leave the default endpoint's API key empty, got %q", config.Endpoints[0].APIKey)
+	}
+}
+
+func TestNewWithConfigBackfillsDefaultEndpointAPIKey(t *testing.T) {
+	c, err := NewWithConfig("my-project-key", Config{PersonalApiKey: "my-personal-key"})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %s", err)
+	}
+	defer c.Close()
+
+	impl := c.(*client)
+
+	if len(impl.Endpoints) != 1 {
+		t.Fatalf("expected exactly one endpoint, got %d", len(impl.Endpoints))
+	}
+
+	if impl.Endpoints[0].APIKey != "my-project-key" {
+		t.Errorf("expected the default endpoint to use the project API key %q, got %q", "my-project-key", impl.Endpoints[0].APIKey)
+	}
+}
+
+func TestNewWithConfigDoesNotOverrideExplicitEndpointAPIKey(t *testing.T) {
+	c, err := NewWithConfig("my-project-key", Config{
+		Endpoints: []EndpointConfig{
+			{URL: "https://staging.example.com", APIKey: "staging-key"},
+			{URL: "https://prod.example.com"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %s", err)
+	}
+	defer c.Close()
+
+	impl := c.(*client)
+
+	if impl.Endpoints[0].APIKey != "staging-key" {
+		t.Errorf("expected the explicit API key to be preserved, got %q", impl.Endpoints[0].APIKey)
+	}
+
+	if impl.Endpoints[1].APIKey != "my-project-key" {
+		t.Errorf("expected the endpoint without a key to be backfilled, got %q", impl.Endpoints[1].APIKey)
+	}
+}
@@ -0,0 +1,34 @@
+package posthog
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ConfigError is returned by Config.validate (and therefore NewWithConfig)
+// when one of the fields of a Config object was set to an invalid value.
+type ConfigError struct {
+	Reason string
+	Field  string
+	Value  interface{}
+}
+
+func (e ConfigError) Error() string {
+	return fmt.Sprintf("posthog.Config.%s: %s (%v)", e.Field, e.Reason, e.Value)
+}
+
+// FieldError is returned by Message.Validate when a required field of a
+// message was missing or malformed.
+type FieldError struct {
+	Type  string
+	Name  string
+	Value interface{}
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("posthog.%s: invalid value for %s: %#v", e.Type, e.Name, e.Value)
+}
+
+// ErrQueueFull is returned by Client.Enqueue when the internal queue has
+// reached Config.MaxQueueSize and Config.OverflowPolicy is ErrorPolicy.
+var ErrQueueFull = errors.New("posthog: message queue is full")
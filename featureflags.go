@@ -0,0 +1,234 @@
+package posthog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// FeatureFlagPayload describes a feature flag evaluation request.
+type FeatureFlagPayload struct {
+	Key        string
+	DistinctId string
+}
+
+// decideResponse is the subset of PostHog's /decide response used for local
+// feature flag evaluation.
+type decideResponse struct {
+	FeatureFlags map[string]interface{} `json:"featureFlags"`
+	Cohorts      json.RawMessage        `json:"cohorts"`
+}
+
+// featureFlagsPoller owns the most recently loaded feature flag definitions
+// and refreshes them on a schedule derived from
+// Config.nextFeatureFlagsPollingTick.
+type featureFlagsPoller struct {
+	client *client
+
+	mu      sync.RWMutex
+	flags   map[string]interface{}
+	cohorts json.RawMessage
+	loaded  bool
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+func newFeatureFlagsPoller(c *client) *featureFlagsPoller {
+	return &featureFlagsPoller{
+		client: c,
+		flags:  map[string]interface{}{},
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// bootstrap seeds the poller with previously cached or bundled flag
+// definitions, ahead of the first network poll.
+func (p *featureFlagsPoller) bootstrap(cache *flagsCache) {
+	if cache == nil {
+		return
+	}
+
+	var flags map[string]interface{}
+	if len(cache.Flags) != 0 {
+		if err := json.Unmarshal(cache.Flags, &flags); err != nil {
+			p.client.Logger.Errorf("posthog: failed to decode bootstrapped feature flags: %s", err)
+			return
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if flags != nil {
+		p.flags = flags
+	}
+	p.cohorts = cache.Cohorts
+}
+
+// snapshot returns the currently loaded flag definitions in the format
+// written to Config.FlagsCachePath.
+func (p *featureFlagsPoller) snapshot() flagsCache {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	data, _ := json.Marshal(p.flags)
+	return flagsCache{Flags: data, Cohorts: p.cohorts}
+}
+
+// get returns the value of a single feature flag, if it's been loaded.
+func (p *featureFlagsPoller) get(key string) (interface{}, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	value, ok := p.flags[key]
+	return value, ok
+}
+
+// run polls the flags endpoint until stop is called.
+func (p *featureFlagsPoller) run() {
+	defer close(p.done)
+
+	backoff := newPollingBackoff(p.client.DefaultFeatureFlagsPollingInterval)
+	lastPollFailed := false
+
+	for {
+		wait := p.client.Config.nextFeatureFlagsPollingTick(backoff, lastPollFailed)
+
+		select {
+		case <-time.After(wait):
+		case <-p.quit:
+			return
+		}
+
+		err := p.poll()
+		lastPollFailed = isRetryablePollError(err)
+
+		if err != nil {
+			p.client.Logger.Errorf("posthog: failed to poll feature flags: %s", err)
+			continue
+		}
+
+		p.notifyLoaded()
+	}
+}
+
+// notifyLoaded fires Callback.Success after a successful poll, unless this
+// is the first load since client start and DisableNotifierOnInit is set.
+func (p *featureFlagsPoller) notifyLoaded() {
+	p.mu.Lock()
+	first := !p.loaded
+	p.loaded = true
+	p.mu.Unlock()
+
+	if p.client.Callback == nil {
+		return
+	}
+
+	if first && p.client.DisableNotifierOnInit {
+		return
+	}
+
+	p.client.Callback.Success(APIMessage{Type: "feature_flags_reload"})
+}
+
+func (p *featureFlagsPoller) stop() {
+	close(p.quit)
+	<-p.done
+}
+
+// poll fetches the latest feature flag definitions from the decide
+// endpoint and replaces the poller's in-memory copy on success.
+func (p *featureFlagsPoller) poll() error {
+	endpoint := p.client.Endpoint
+	if len(p.client.Endpoints) > 0 {
+		endpoint = p.client.Endpoints[0].URL
+	}
+
+	req, err := http.NewRequest("GET", endpoint+"/decide/?v=2", nil)
+	if err != nil {
+		return err
+	}
+
+	q := url.Values{}
+	if len(p.client.PersonalApiKey) != 0 {
+		q.Set("personal_api_key", p.client.PersonalApiKey)
+	}
+	req.URL.RawQuery = q.Encode()
+
+	httpClient := &http.Client{
+		Transport: p.client.Transport,
+		Timeout:   p.client.FeatureFlagRequestTimeout,
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return &pollStatusError{StatusCode: resp.StatusCode}
+	}
+
+	var decoded decideResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return fmt.Errorf("posthog: decoding feature flags response: %w", err)
+	}
+
+	p.mu.Lock()
+	p.flags = decoded.FeatureFlags
+	p.cohorts = decoded.Cohorts
+	p.mu.Unlock()
+
+	return nil
+}
+
+// GetFeatureFlag returns the value of a feature flag for the given distinct
+// ID, as last loaded by the background poller. Unless
+// Config.DisableFeatureFlagCalledEvents is set, it also captures a
+// `$feature_flag_called` event recording the evaluation.
+func (c *client) GetFeatureFlag(flag FeatureFlagPayload) (interface{}, error) {
+	value, ok := c.poller.get(flag.Key)
+	if !ok {
+		value = false
+	}
+
+	if !c.DisableFeatureFlagCalledEvents {
+		err := c.Enqueue(Capture{
+			DistinctId: flag.DistinctId,
+			Event:      "$feature_flag_called",
+			Properties: Properties{
+				"$feature_flag":          flag.Key,
+				"$feature_flag_response": value,
+			},
+		})
+		if err != nil {
+			c.Logger.Errorf("posthog: failed to capture $feature_flag_called for %q: %s", flag.Key, err)
+		}
+	}
+
+	return value, nil
+}
+
+// IsFeatureEnabled reports whether a feature flag evaluates to a truthy
+// value (boolean true, or a non-empty string variant) for the given
+// distinct ID.
+func (c *client) IsFeatureEnabled(flag FeatureFlagPayload) (bool, error) {
+	value, err := c.GetFeatureFlag(flag)
+	if err != nil {
+		return false, err
+	}
+
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		return len(v) > 0, nil
+	default:
+		return false, nil
+	}
+}
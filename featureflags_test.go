@@ -0,0 +1,168 @@
+package posthog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetFeatureFlagCapturesCalledEventByDefault(t *testing.T) {
+	c := &client{
+		Config: Config{Logger: newDefaultLogger()},
+		msgs:   make(chan Message, 1),
+	}
+	c.poller = newFeatureFlagsPoller(c)
+	c.poller.bootstrap(&flagsCache{Flags: []byte(`{"my-flag":true}`)})
+
+	value, err := c.GetFeatureFlag(FeatureFlagPayload{Key: "my-flag", DistinctId: "user-1"})
+	if err != nil {
+		t.Fatalf("GetFeatureFlag: %s", err)
+	}
+
+	if value != true {
+		t.Errorf("expected my-flag to evaluate to true, got %v", value)
+	}
+
+	select {
+	case msg := <-c.msgs:
+		if msg.APIfy().Event != "$feature_flag_called" {
+			t.Errorf("expected a $feature_flag_called capture, got %q", msg.APIfy().Event)
+		}
+	default:
+		t.Error("expected a $feature_flag_called event to be captured")
+	}
+}
+
+func TestGetFeatureFlagSuppressesCalledEventWhenDisabled(t *testing.T) {
+	c := &client{
+		Config: Config{Logger: newDefaultLogger(), DisableFeatureFlagCalledEvents: true},
+		msgs:   make(chan Message, 1),
+	}
+	c.poller = newFeatureFlagsPoller(c)
+	c.poller.bootstrap(&flagsCache{Flags: []byte(`{"my-flag":true}`)})
+
+	if _, err := c.GetFeatureFlag(FeatureFlagPayload{Key: "my-flag", DistinctId: "user-1"}); err != nil {
+		t.Fatalf("GetFeatureFlag: %s", err)
+	}
+
+	select {
+	case msg := <-c.msgs:
+		t.Errorf("expected no captured event, got %q", msg.APIfy().Event)
+	default:
+	}
+}
+
+func TestIsFeatureEnabled(t *testing.T) {
+	c := &client{
+		Config: Config{Logger: newDefaultLogger(), DisableFeatureFlagCalledEvents: true},
+	}
+	c.poller = newFeatureFlagsPoller(c)
+	c.poller.bootstrap(&flagsCache{Flags: []byte(`{"bool-flag":true,"variant-flag":"control","off-flag":false}`)})
+
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"bool-flag", true},
+		{"variant-flag", true},
+		{"off-flag", false},
+		{"missing-flag", false},
+	}
+
+	for _, test := range tests {
+		got, err := c.IsFeatureEnabled(FeatureFlagPayload{Key: test.key, DistinctId: "user-1"})
+		if err != nil {
+			t.Fatalf("IsFeatureEnabled(%q): %s", test.key, err)
+		}
+		if got != test.want {
+			t.Errorf("IsFeatureEnabled(%q) = %v, want %v", test.key, got, test.want)
+		}
+	}
+}
+
+func TestNotifyLoadedSuppressesFirstCallbackWhenDisabled(t *testing.T) {
+	callback := &recordingCallback{}
+
+	c := &client{Config: Config{Callback: callback, DisableNotifierOnInit: true}}
+	poller := newFeatureFlagsPoller(c)
+
+	poller.notifyLoaded()
+
+	callback.mu.Lock()
+	first := callback.successes
+	callback.mu.Unlock()
+
+	if first != 0 {
+		t.Errorf("expected the first load's callback to be suppressed, got %d successes", first)
+	}
+
+	poller.notifyLoaded()
+
+	callback.mu.Lock()
+	second := callback.successes
+	callback.mu.Unlock()
+
+	if second != 1 {
+		t.Errorf("expected the second load to notify the callback, got %d successes", second)
+	}
+}
+
+func TestPollPersistsCohortsFromLiveResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"featureFlags":{"my-flag":true},"cohorts":{"1":{"some":"definition"}}}`))
+	}))
+	defer server.Close()
+
+	c := &client{
+		Config: Config{Endpoint: server.URL, Transport: http.DefaultTransport, Logger: newDefaultLogger()},
+	}
+	c.poller = newFeatureFlagsPoller(c)
+
+	if err := c.poller.poll(); err != nil {
+		t.Fatalf("poll: %s", err)
+	}
+
+	snapshot := c.poller.snapshot()
+	if string(snapshot.Cohorts) != `{"1":{"some":"definition"}}` {
+		t.Errorf("expected poll to persist the response's cohorts, got %s", snapshot.Cohorts)
+	}
+}
+
+func TestPollReturnsNonRetryableErrorForClientErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	c := &client{
+		Config: Config{Endpoint: server.URL, Transport: http.DefaultTransport, Logger: newDefaultLogger()},
+	}
+	c.poller = newFeatureFlagsPoller(c)
+
+	err := c.poller.poll()
+	if err == nil {
+		t.Fatal("expected poll to return an error for a 403 response")
+	}
+
+	if isRetryablePollError(err) {
+		t.Errorf("expected a 403 not to be classified as retryable, got %v", err)
+	}
+}
+
+func TestNotifyLoadedAlwaysFiresWhenNotDisabled(t *testing.T) {
+	callback := &recordingCallback{}
+
+	c := &client{Config: Config{Callback: callback}}
+	poller := newFeatureFlagsPoller(c)
+
+	poller.notifyLoaded()
+	poller.notifyLoaded()
+
+	callback.mu.Lock()
+	defer callback.mu.Unlock()
+
+	if callback.successes != 2 {
+		t.Errorf("expected both loads to notify the callback, got %d successes", callback.successes)
+	}
+}
@@ -0,0 +1,64 @@
+package posthog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// flagsCache is the on-disk/bootstrap representation of the locally cached
+// feature flag definitions and cohort payload, as loaded from the
+// `/decide` endpoint. It is what gets written to
+// Config.FlagsCachePath on Close and read back from either
+// Config.FlagsBootstrap or FlagsCachePath on startup.
+type flagsCache struct {
+	Flags   json.RawMessage `json:"flags"`
+	Cohorts json.RawMessage `json:"cohorts"`
+}
+
+// loadFlagsBootstrap resolves the feature flag definitions that should be
+// loaded synchronously before the first poll: FlagsBootstrap if set,
+// otherwise the contents of FlagsCachePath if it exists. It returns a nil
+// cache, with no error, if neither source is available.
+func loadFlagsBootstrap(c *Config) (*flagsCache, error) {
+	data := c.FlagsBootstrap
+
+	if len(data) == 0 && len(c.FlagsCachePath) != 0 {
+		fileData, err := os.ReadFile(c.FlagsCachePath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("posthog: reading flags cache %q: %w", c.FlagsCachePath, err)
+		}
+		data = fileData
+	}
+
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var cache flagsCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("posthog: decoding flags cache: %w", err)
+	}
+
+	return &cache, nil
+}
+
+// writeFlagsCache atomically writes cache to path, so a crash or concurrent
+// read never observes a partially written file.
+func writeFlagsCache(path string, cache flagsCache) error {
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return fmt.Errorf("posthog: marshaling flags cache: %w", err)
+	}
+
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("posthog: writing flags cache %q: %w", path, err)
+	}
+
+	return os.Rename(tmp, path)
+}
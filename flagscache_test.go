@@ -0,0 +1,77 @@
+package posthog
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFlagsBootstrapPrefersInlineBootstrapOverCacheFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "flags.json")
+
+	if err := writeFlagsCache(path, flagsCache{Flags: []byte(`{"from-file":true}`)}); err != nil {
+		t.Fatalf("writeFlagsCache: %s", err)
+	}
+
+	config := Config{
+		FlagsCachePath: path,
+		FlagsBootstrap: []byte(`{"flags":{"from-bootstrap":true}}`),
+	}
+
+	cache, err := loadFlagsBootstrap(&config)
+	if err != nil {
+		t.Fatalf("loadFlagsBootstrap: %s", err)
+	}
+
+	if cache == nil || string(cache.Flags) != `{"from-bootstrap":true}` {
+		t.Fatalf("expected inline bootstrap to win, got %+v", cache)
+	}
+}
+
+func TestLoadFlagsBootstrapFallsBackToCacheFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "flags.json")
+
+	if err := writeFlagsCache(path, flagsCache{Flags: []byte(`{"beta":true}`)}); err != nil {
+		t.Fatalf("writeFlagsCache: %s", err)
+	}
+
+	config := Config{FlagsCachePath: path}
+
+	cache, err := loadFlagsBootstrap(&config)
+	if err != nil {
+		t.Fatalf("loadFlagsBootstrap: %s", err)
+	}
+
+	if cache == nil || string(cache.Flags) != `{"beta":true}` {
+		t.Fatalf("expected cache file contents, got %+v", cache)
+	}
+}
+
+func TestLoadFlagsBootstrapReturnsNilWhenNeitherIsSet(t *testing.T) {
+	cache, err := loadFlagsBootstrap(&Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if cache != nil {
+		t.Fatalf("expected a nil cache, got %+v", cache)
+	}
+}
+
+func TestFeatureFlagsPollerBootstrapAndSnapshotRoundTrip(t *testing.T) {
+	c := &client{}
+	poller := newFeatureFlagsPoller(c)
+
+	poller.bootstrap(&flagsCache{Flags: []byte(`{"beta":true}`)})
+
+	value, ok := poller.get("beta")
+	if !ok || value != true {
+		t.Fatalf("expected beta=true after bootstrap, got %v, %v", value, ok)
+	}
+
+	snapshot := poller.snapshot()
+	if string(snapshot.Flags) != `{"beta":true}` {
+		t.Errorf("expected snapshot to round-trip the bootstrapped flags, got %s", snapshot.Flags)
+	}
+}
@@ -0,0 +1,29 @@
+package posthog
+
+import (
+	"log"
+	"os"
+)
+
+// Logger represents the interface used by the client to output messages
+// generated by background operations.
+type Logger interface {
+	Logf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+func newDefaultLogger() Logger {
+	return &stdLogger{logger: log.New(os.Stderr, "posthog ", log.LstdFlags)}
+}
+
+type stdLogger struct {
+	logger *log.Logger
+}
+
+func (l *stdLogger) Logf(format string, args ...interface{}) {
+	l.logger.Printf(format, args...)
+}
+
+func (l *stdLogger) Errorf(format string, args ...interface{}) {
+	l.logger.Printf(format, args...)
+}
@@ -0,0 +1,56 @@
+package posthog
+
+import "time"
+
+// Message is the interface implemented by every type of event the client
+// can enqueue.
+type Message interface {
+	// APIfy converts the message to the representation that gets marshaled
+	// into the batch sent to the API.
+	APIfy() APIMessage
+
+	// Validate checks that the message is well formed, returning a
+	// FieldError describing the first problem found, or nil.
+	Validate() error
+}
+
+// APIMessage is the normalized representation of a single message, built by
+// Message.APIfy, that batches are made of regardless of which concrete
+// Message type produced them.
+type APIMessage struct {
+	Type       string     `json:"type"`
+	Event      string     `json:"event,omitempty"`
+	DistinctId string     `json:"distinct_id,omitempty"`
+	Properties Properties `json:"properties,omitempty"`
+	Timestamp  time.Time  `json:"timestamp,omitempty"`
+}
+
+// Capture records that an event happened.
+type Capture struct {
+	DistinctId string
+	Event      string
+	Properties Properties
+	Timestamp  time.Time
+}
+
+func (c Capture) APIfy() APIMessage {
+	return APIMessage{
+		Type:       "capture",
+		Event:      c.Event,
+		DistinctId: c.DistinctId,
+		Properties: c.Properties,
+		Timestamp:  c.Timestamp,
+	}
+}
+
+func (c Capture) Validate() error {
+	if len(c.Event) == 0 {
+		return FieldError{Type: "Capture", Name: "Event", Value: c.Event}
+	}
+
+	if len(c.DistinctId) == 0 {
+		return FieldError{Type: "Capture", Name: "DistinctId", Value: c.DistinctId}
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package posthog
+
+// OverflowPolicy determines what Enqueue does when the client's internal
+// queue has reached Config.MaxQueueSize.
+type OverflowPolicy int
+
+const (
+	// DropOldest discards the oldest queued message to make room for the
+	// new one. This is the zero value and therefore the default.
+	DropOldest OverflowPolicy = iota
+
+	// DropNewest discards the message passed to Enqueue instead of queuing
+	// it.
+	DropNewest
+
+	// Block makes Enqueue wait until the queue has room.
+	Block
+
+	// ErrorPolicy makes Enqueue return an error instead of queuing the
+	// message.
+	ErrorPolicy
+)
+
+func (p OverflowPolicy) valid() bool {
+	return p >= DropOldest && p <= ErrorPolicy
+}
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case DropOldest:
+		return "DropOldest"
+	case DropNewest:
+		return "DropNewest"
+	case Block:
+		return "Block"
+	case ErrorPolicy:
+		return "Error"
+	default:
+		return "Unknown"
+	}
+}
@@ -0,0 +1,32 @@
+package posthog
+
+import "testing"
+
+func TestOverflowPolicyString(t *testing.T) {
+	tests := []struct {
+		policy OverflowPolicy
+		want   string
+	}{
+		{DropOldest, "DropOldest"},
+		{DropNewest, "DropNewest"},
+		{Block, "Block"},
+		{ErrorPolicy, "Error"},
+		{OverflowPolicy(99), "Unknown"},
+	}
+
+	for _, test := range tests {
+		if got := test.policy.String(); got != test.want {
+			t.Errorf("OverflowPolicy(%d).String() = %q, want %q", test.policy, got, test.want)
+		}
+	}
+}
+
+func TestOverflowPolicyValid(t *testing.T) {
+	if !DropOldest.valid() || !DropNewest.valid() || !Block.valid() || !ErrorPolicy.valid() {
+		t.Error("expected all defined overflow policies to be valid")
+	}
+
+	if OverflowPolicy(99).valid() {
+		t.Error("expected an undefined overflow policy to be invalid")
+	}
+}
@@ -0,0 +1,109 @@
+package posthog
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// pollStatusError is returned by featureFlagsPoller.poll when the decide
+// endpoint responds with a non-2xx status, carrying the status code so the
+// caller can tell a transient server-side failure (429, 5xx) from one that
+// retrying won't fix (e.g. 400/403 from a bad key).
+type pollStatusError struct {
+	StatusCode int
+}
+
+func (e *pollStatusError) Error() string {
+	return fmt.Sprintf("posthog: unexpected status code %d fetching feature flags", e.StatusCode)
+}
+
+// isRetryablePollError reports whether err represents a poll failure that
+// adaptive backoff should engage for: a 429 or 5xx response, or any error
+// that didn't even reach the server (network failures, timeouts). Other
+// 4xx responses (bad API key, malformed request) won't be fixed by
+// retrying, so they don't grow the polling delay.
+func isRetryablePollError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var statusErr *pollStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+
+	return true
+}
+
+// maxPollingBackoff caps how long the client will wait between feature flag
+// polls after a run of failed requests, regardless of how many have failed.
+const maxPollingBackoff = 30 * time.Minute
+
+// withJitter perturbs interval by a uniformly random offset in
+// [-jitter, +jitter]. It is used to avoid many instances of a service
+// polling feature flags in lockstep after booting at the same time.
+func withJitter(interval, jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+
+	offset := time.Duration(rand.Int63n(int64(2*jitter+1))) - jitter
+
+	if interval+offset < 0 {
+		return 0
+	}
+
+	return interval + offset
+}
+
+// pollingBackoff tracks the adaptive backoff applied to feature flag
+// polling when the flags endpoint responds with 429 or 5xx errors. Each
+// failure doubles the delay, capped at maxPollingBackoff; the first
+// successful poll resets it back to the base interval.
+type pollingBackoff struct {
+	base    time.Duration
+	current time.Duration
+}
+
+func newPollingBackoff(base time.Duration) *pollingBackoff {
+	return &pollingBackoff{base: base, current: base}
+}
+
+// next returns the delay to wait before the next poll, given whether the
+// most recent poll failed.
+func (b *pollingBackoff) next(failed bool) time.Duration {
+	if !failed {
+		b.current = b.base
+		return b.current
+	}
+
+	b.current *= 2
+	if b.current > maxPollingBackoff {
+		b.current = maxPollingBackoff
+	}
+
+	return b.current
+}
+
+// nextFeatureFlagsPollingTick computes the delay before the next feature
+// flag poll. The base interval is either DefaultFeatureFlagsPollingInterval
+// or, if set, the value returned by Config.NextFeatureFlagsPollingTick;
+// adaptive backoff is then applied on top of that base so a custom tick
+// function doesn't lose backoff on 429/5xx responses, and jitter is applied
+// last.
+func (c *Config) nextFeatureFlagsPollingTick(backoff *pollingBackoff, lastPollFailed bool) time.Duration {
+	if c.NextFeatureFlagsPollingTick != nil {
+		backoff.base = c.NextFeatureFlagsPollingTick()
+	}
+
+	interval := backoff.next(lastPollFailed)
+
+	if c.EnablePollingJitter {
+		interval = withJitter(interval, c.PollingJitter)
+	}
+
+	return interval
+}
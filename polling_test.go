@@ -0,0 +1,105 @@
+package posthog
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithJitterStaysWithinBounds(t *testing.T) {
+	base := 10 * time.Second
+	jitter := 2 * time.Second
+
+	for i := 0; i < 100; i++ {
+		got := withJitter(base, jitter)
+		if got < base-jitter || got > base+jitter {
+			t.Fatalf("withJitter(%s, %s) = %s, out of bounds", base, jitter, got)
+		}
+	}
+}
+
+func TestWithJitterNoOpWhenZero(t *testing.T) {
+	if got := withJitter(5*time.Second, 0); got != 5*time.Second {
+		t.Errorf("expected no jitter to leave interval unchanged, got %s", got)
+	}
+}
+
+func TestPollingBackoffGrowsAndResets(t *testing.T) {
+	b := newPollingBackoff(time.Second)
+
+	if got := b.next(false); got != time.Second {
+		t.Fatalf("expected base interval on success, got %s", got)
+	}
+
+	if got := b.next(true); got != 2*time.Second {
+		t.Fatalf("expected backoff to double after a failure, got %s", got)
+	}
+
+	if got := b.next(true); got != 4*time.Second {
+		t.Fatalf("expected backoff to double again after another failure, got %s", got)
+	}
+
+	if got := b.next(false); got != time.Second {
+		t.Fatalf("expected backoff to reset to base interval after success, got %s", got)
+	}
+}
+
+func TestPollingBackoffCapsAtMax(t *testing.T) {
+	b := newPollingBackoff(maxPollingBackoff)
+
+	if got := b.next(true); got != maxPollingBackoff {
+		t.Fatalf("expected backoff to cap at %s, got %s", maxPollingBackoff, got)
+	}
+}
+
+func TestNextFeatureFlagsPollingTickAppliesBackoffEvenWithCustomTick(t *testing.T) {
+	config := &Config{
+		NextFeatureFlagsPollingTick: func() time.Duration { return time.Second },
+	}
+
+	backoff := newPollingBackoff(time.Second)
+
+	if got := config.nextFeatureFlagsPollingTick(backoff, false); got != time.Second {
+		t.Fatalf("expected the base custom tick on success, got %s", got)
+	}
+
+	if got := config.nextFeatureFlagsPollingTick(backoff, true); got != 2*time.Second {
+		t.Fatalf("expected backoff to still double on failure with a custom tick set, got %s", got)
+	}
+}
+
+func TestIsRetryablePollErrorClassifiesStatusCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"429", &pollStatusError{StatusCode: 429}, true},
+		{"500", &pollStatusError{StatusCode: 500}, true},
+		{"503", &pollStatusError{StatusCode: 503}, true},
+		{"400", &pollStatusError{StatusCode: 400}, false},
+		{"403", &pollStatusError{StatusCode: 403}, false},
+		{"network error", errors.New("connection refused"), true},
+	}
+
+	for _, test := range tests {
+		if got := isRetryablePollError(test.err); got != test.want {
+			t.Errorf("isRetryablePollError(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
+
+func TestNextFeatureFlagsPollingTickAppliesJitter(t *testing.T) {
+	config := &Config{
+		EnablePollingJitter: true,
+		PollingJitter:       time.Second,
+	}
+
+	backoff := newPollingBackoff(10 * time.Second)
+
+	got := config.nextFeatureFlagsPollingTick(backoff, false)
+	if got < 9*time.Second || got > 11*time.Second {
+		t.Fatalf("expected jittered tick within bounds, got %s", got)
+	}
+}
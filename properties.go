@@ -0,0 +1,17 @@
+package posthog
+
+// Properties is a map of string keys to arbitrary values, attached to
+// events and feature flag evaluations as extra metadata.
+type Properties map[string]interface{}
+
+// NewProperties returns a new empty set of properties.
+func NewProperties() Properties {
+	return make(Properties, 10)
+}
+
+// Set adds a key/value pair to the set of properties and returns the
+// receiver so calls can be chained.
+func (p Properties) Set(name string, value interface{}) Properties {
+	p[name] = value
+	return p
+}
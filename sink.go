@@ -0,0 +1,43 @@
+package posthog
+
+import (
+	"fmt"
+	"os"
+)
+
+// Sink is the interface through which a client delivers encoded batches of
+// messages. Implementing this interface allows captured events to be
+// diverted or mirrored to arbitrary destinations instead of (or alongside)
+// PostHog's own ingestion endpoint.
+//
+// body is the raw JSON payload of the batch, exactly as it would otherwise
+// be posted to Config.Endpoint.
+type Sink interface {
+	Send(body []byte) error
+}
+
+// SinkFunc adapts an ordinary function into a Sink.
+type SinkFunc func(body []byte) error
+
+func (f SinkFunc) Send(body []byte) error {
+	return f(body)
+}
+
+// NewFileSink returns a Sink that appends each batch to path as a single
+// newline-delimited JSON (NDJSON) line, creating the file if needed. This is
+// useful for local development or for piping captured events into another
+// ingestion pipeline.
+func NewFileSink(path string) (Sink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("posthog: opening file sink %q: %w", path, err)
+	}
+
+	return SinkFunc(func(body []byte) error {
+		if _, err := file.Write(append(body, '\n')); err != nil {
+			return fmt.Errorf("posthog: writing to file sink %q: %w", path, err)
+		}
+
+		return nil
+	}), nil
+}
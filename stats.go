@@ -0,0 +1,29 @@
+package posthog
+
+import "time"
+
+// Stats is a snapshot of a client's internal queue and delivery state,
+// returned by Client.Stats(). It is intended to be read periodically and
+// exported to a metrics system like Prometheus.
+type Stats struct {
+	// The number of messages currently held in the internal queue.
+	Queued int
+
+	// The number of batches currently being sent to the configured
+	// endpoint(s).
+	InFlight int
+
+	// The number of messages dropped so far, broken down by the
+	// OverflowPolicy that caused the drop. Messages rejected with
+	// ErrQueueFull are counted under ErrorPolicy; Block never drops
+	// messages and is not represented here.
+	Dropped map[OverflowPolicy]uint64
+
+	// The total number of bytes successfully sent since the client was
+	// created.
+	BytesSent uint64
+
+	// How long the most recent flush took, from the decision to send a
+	// batch to the response being received.
+	LastFlushLatency time.Duration
+}